@@ -0,0 +1,70 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataSourceAwsGuardDutyPublishingDestination() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceAwsGuardDutyPublishingDestinationRead,
+
+		Schema: map[string]*schema.Schema{
+			"detector_id": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"destination_id": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"destination_arn": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"destination_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"kms_key_arn": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceAwsGuardDutyPublishingDestinationRead(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*AWSClient).guarddutyconn
+
+	detectorID := d.Get("detector_id").(string)
+	destinationID := d.Get("destination_id").(string)
+
+	input := &guardduty.DescribePublishingDestinationInput{
+		DetectorId:    aws.String(detectorID),
+		DestinationId: aws.String(destinationID),
+	}
+
+	gdo, err := conn.DescribePublishingDestination(input)
+	if err != nil {
+		return fmt.Errorf("error reading GuardDuty Publishing Destination (%s:%s): %w", detectorID, destinationID, err)
+	}
+
+	d.SetId(fmt.Sprintf("%s:%s", detectorID, destinationID))
+	d.Set("destination_type", gdo.DestinationType)
+	d.Set("status", gdo.Status)
+
+	if gdo.DestinationProperties != nil {
+		d.Set("destination_arn", gdo.DestinationProperties.DestinationArn)
+		d.Set("kms_key_arn", gdo.DestinationProperties.KmsKeyArn)
+	}
+
+	return nil
+}
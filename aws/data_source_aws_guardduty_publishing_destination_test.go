@@ -0,0 +1,40 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+)
+
+func TestAccAwsGuardDutyPublishingDestinationDataSource_basic(t *testing.T) {
+	dataSourceName := "data.aws_guardduty_publishing_destination.test"
+	resourceName := "aws_guardduty_publishing_destination.test"
+	bucketName := fmt.Sprintf("tf-test-%s", acctest.RandString(5))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckAwsGuardDutyPublishingDestinationDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAwsGuardDutyPublishingDestinationDataSourceConfig_basic(bucketName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(dataSourceName, "destination_arn", resourceName, "destination_arn"),
+					resource.TestCheckResourceAttrPair(dataSourceName, "destination_type", resourceName, "destination_type"),
+					resource.TestCheckResourceAttrSet(dataSourceName, "status"),
+				),
+			},
+		},
+	})
+}
+
+func testAccAwsGuardDutyPublishingDestinationDataSourceConfig_basic(bucketName string) string {
+	return testAccAwsGuardDutyPublishDestinationConfig_basic(bucketName) + `
+data "aws_guardduty_publishing_destination" "test" {
+  detector_id    = aws_guardduty_publishing_destination.test.detector_id
+  destination_id = split(":", aws_guardduty_publishing_destination.test.id)[1]
+}
+`
+}
@@ -0,0 +1,95 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataSourceAwsGuardDutyPublishingDestinations() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceAwsGuardDutyPublishingDestinationsRead,
+
+		Schema: map[string]*schema.Schema{
+			"detector_id": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"destinations": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"destination_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"destination_arn": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"destination_type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"status": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceAwsGuardDutyPublishingDestinationsRead(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*AWSClient).guarddutyconn
+
+	detectorID := d.Get("detector_id").(string)
+
+	input := &guardduty.ListPublishingDestinationsInput{
+		DetectorId: aws.String(detectorID),
+	}
+
+	var destinationIds []*string
+	err := conn.ListPublishingDestinationsPages(input, func(page *guardduty.ListPublishingDestinationsOutput, lastPage bool) bool {
+		for _, destination := range page.Destinations {
+			destinationIds = append(destinationIds, destination.DestinationId)
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return fmt.Errorf("error listing GuardDuty Publishing Destinations (%s): %w", detectorID, err)
+	}
+
+	destinations := make([]map[string]interface{}, 0, len(destinationIds))
+	for _, destinationID := range destinationIds {
+		gdo, err := conn.DescribePublishingDestination(&guardduty.DescribePublishingDestinationInput{
+			DetectorId:    aws.String(detectorID),
+			DestinationId: destinationID,
+		})
+		if err != nil {
+			return fmt.Errorf("error describing GuardDuty Publishing Destination (%s:%s): %w", detectorID, aws.StringValue(destinationID), err)
+		}
+
+		destination := map[string]interface{}{
+			"destination_id":   aws.StringValue(destinationID),
+			"destination_type": aws.StringValue(gdo.DestinationType),
+			"status":           aws.StringValue(gdo.Status),
+		}
+		if gdo.DestinationProperties != nil {
+			destination["destination_arn"] = aws.StringValue(gdo.DestinationProperties.DestinationArn)
+		}
+		destinations = append(destinations, destination)
+	}
+
+	d.SetId(detectorID)
+	if err := d.Set("destinations", destinations); err != nil {
+		return fmt.Errorf("error setting destinations: %w", err)
+	}
+
+	return nil
+}
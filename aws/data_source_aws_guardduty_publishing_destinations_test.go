@@ -0,0 +1,39 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+)
+
+func TestAccAwsGuardDutyPublishingDestinationsDataSource_basic(t *testing.T) {
+	dataSourceName := "data.aws_guardduty_publishing_destinations.test"
+	resourceName := "aws_guardduty_publishing_destination.test"
+	bucketName := fmt.Sprintf("tf-test-%s", acctest.RandString(5))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckAwsGuardDutyPublishingDestinationDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAwsGuardDutyPublishingDestinationsDataSourceConfig_basic(bucketName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "destinations.#", "1"),
+					resource.TestCheckResourceAttrPair(dataSourceName, "destinations.0.destination_arn", resourceName, "destination_arn"),
+					resource.TestCheckResourceAttrPair(dataSourceName, "destinations.0.destination_type", resourceName, "destination_type"),
+				),
+			},
+		},
+	})
+}
+
+func testAccAwsGuardDutyPublishingDestinationsDataSourceConfig_basic(bucketName string) string {
+	return testAccAwsGuardDutyPublishDestinationConfig_basic(bucketName) + `
+data "aws_guardduty_publishing_destinations" "test" {
+  detector_id = aws_guardduty_publishing_destination.test.detector_id
+}
+`
+}
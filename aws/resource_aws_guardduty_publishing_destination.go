@@ -10,13 +10,25 @@ import (
 	"github.com/aws/aws-sdk-go/service/guardduty"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 )
 
 // Constants not currently provided by the AWS Go SDK
 const (
 	guardDutyPublishingStatusFailed = "FAILED"
+
+	// guardDutyDestinationTypeKinesis is not currently modeled as a
+	// guardduty.DestinationType constant by the AWS Go SDK.
+	guardDutyDestinationTypeKinesis = "KINESIS"
 )
 
+func guardDutyPublishingDestinationType_Values() []string {
+	return []string{
+		guardduty.DestinationTypeS3,
+		guardDutyDestinationTypeKinesis,
+	}
+}
+
 func resourceAwsGuardDutyPublishingDestination() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceAwsGuardDutyPublishingDestinationCreate,
@@ -28,6 +40,13 @@ func resourceAwsGuardDutyPublishingDestination() *schema.Resource {
 			State: schema.ImportStatePassthrough,
 		},
 
+		CustomizeDiff: resourceAwsGuardDutyPublishingDestinationCustomizeDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"detector_id": {
 				Type:     schema.TypeString,
@@ -35,9 +54,11 @@ func resourceAwsGuardDutyPublishingDestination() *schema.Resource {
 				ForceNew: true,
 			},
 			"destination_type": {
-				Type:     schema.TypeString,
-				Optional: true,
-				Default:  guardduty.DestinationTypeS3,
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      guardduty.DestinationTypeS3,
+				ValidateFunc: validation.StringInSlice(guardDutyPublishingDestinationType_Values(), false),
 			},
 			"destination_arn": {
 				Type:         schema.TypeString,
@@ -45,25 +66,92 @@ func resourceAwsGuardDutyPublishingDestination() *schema.Resource {
 				ValidateFunc: validateArn,
 			},
 			"kms_key_arn": {
+				// Only required for destination types that GuardDuty encrypts with a
+				// customer master key, e.g. S3. Destination types such as Kinesis are
+				// encrypted by the service itself and do not need a CMK.
 				Type:         schema.TypeString,
-				Required:     true,
+				Optional:     true,
 				ValidateFunc: validateArn,
 			},
+			"wait_for_publishing": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 		},
 	}
 }
 
+// resourceAwsGuardDutyPublishingDestinationCustomizeDiff enforces the
+// requirements that vary by destination_type: kms_key_arn is mandatory for
+// destination types that GuardDuty CMK-encrypts, and destination_arn must
+// reference the kind of resource the destination type expects.
+func resourceAwsGuardDutyPublishingDestinationCustomizeDiff(diff *schema.ResourceDiff, v interface{}) error {
+	destinationType := diff.Get("destination_type").(string)
+	destinationArn := diff.Get("destination_arn").(string)
+
+	if destinationType == guardduty.DestinationTypeS3 && diff.Get("kms_key_arn").(string) == "" && diff.NewValueKnown("kms_key_arn") {
+		return fmt.Errorf("kms_key_arn is required when destination_type is %q", guardduty.DestinationTypeS3)
+	}
+
+	if destinationArn == "" {
+		return nil
+	}
+
+	return validateGuardDutyPublishingDestinationArn(destinationType, destinationArn)
+}
+
+// guardDutyPublishingDestinationProperties builds the DestinationProperties
+// payload shared by Create and Update, omitting KmsKeyArn entirely when no
+// CMK is configured (e.g. destination_type = "KINESIS") rather than sending
+// an empty string the API would reject as a malformed ARN.
+func guardDutyPublishingDestinationProperties(destinationArn, kmsKeyArn string) *guardduty.DestinationProperties {
+	properties := &guardduty.DestinationProperties{
+		DestinationArn: aws.String(destinationArn),
+	}
+	if kmsKeyArn != "" {
+		properties.KmsKeyArn = aws.String(kmsKeyArn)
+	}
+	return properties
+}
+
+// validateGuardDutyPublishingDestinationArn ensures destination_arn looks
+// like the kind of resource destination_type expects, e.g. an S3 bucket ARN
+// for DestinationTypeS3 or a Kinesis stream ARN for the Kinesis type.
+func validateGuardDutyPublishingDestinationArn(destinationType, destinationArn string) error {
+	switch destinationType {
+	case guardduty.DestinationTypeS3:
+		if !strings.HasPrefix(destinationArn, "arn:aws:s3:::") && !strings.HasPrefix(destinationArn, "arn:aws-us-gov:s3:::") {
+			return fmt.Errorf("destination_arn %q is not a valid S3 bucket ARN for destination_type %q", destinationArn, destinationType)
+		}
+	case guardDutyDestinationTypeKinesis:
+		if !strings.Contains(destinationArn, ":kinesis:") || !strings.Contains(destinationArn, ":stream/") {
+			return fmt.Errorf("destination_arn %q is not a valid Kinesis stream ARN for destination_type %q", destinationArn, destinationType)
+		}
+	}
+	return nil
+}
+
 func resourceAwsGuardDutyPublishingDestinationCreate(d *schema.ResourceData, meta interface{}) error {
 	conn := meta.(*AWSClient).guarddutyconn
 
 	detectorID := d.Get("detector_id").(string)
+	destinationType := d.Get("destination_type").(string)
+	destinationArn := d.Get("destination_arn").(string)
+	kmsKeyArn := d.Get("kms_key_arn").(string)
+
+	if err := guardDutyPreflightCheckDestinationPolicies(meta, destinationType, destinationArn, kmsKeyArn); err != nil {
+		return err
+	}
+
 	input := guardduty.CreatePublishingDestinationInput{
-		DetectorId: aws.String(detectorID),
-		DestinationProperties: &guardduty.DestinationProperties{
-			DestinationArn: aws.String(d.Get("destination_arn").(string)),
-			KmsKeyArn:      aws.String(d.Get("kms_key_arn").(string)),
-		},
-		DestinationType: aws.String(d.Get("destination_type").(string)),
+		DetectorId:            aws.String(detectorID),
+		DestinationProperties: guardDutyPublishingDestinationProperties(destinationArn, kmsKeyArn),
+		DestinationType:       aws.String(destinationType),
 	}
 
 	log.Printf("[DEBUG] Creating GuardDuty publishing destination: %s", input)
@@ -72,22 +160,15 @@ func resourceAwsGuardDutyPublishingDestinationCreate(d *schema.ResourceData, met
 		return fmt.Errorf("Creating GuardDuty publishing destination failed: %s", err.Error())
 	}
 
-	stateConf := &resource.StateChangeConf{
-		Pending:    []string{guardduty.PublishingStatusPendingVerification},
-		Target:     []string{guardduty.PublishingStatusPublishing},
-		Refresh:    guardDutyPublishingDestinationRefreshStatusFunc(conn, *output.DestinationId, detectorID),
-		Timeout:    5 * time.Minute,
-		MinTimeout: 3 * time.Second,
-	}
+	destinationID := aws.StringValue(output.DestinationId)
+	d.SetId(fmt.Sprintf("%s:%s", detectorID, destinationID))
 
-	_, err = stateConf.WaitForState()
-	if err != nil {
-		return fmt.Errorf("Error waiting for GuardDuty PublishingDestination status to be \"%s\": %s",
-			guardduty.PublishingStatusPublishing, err)
+	if d.Get("wait_for_publishing").(bool) {
+		if err := waitForGuardDutyPublishingDestinationPublishing(conn, detectorID, destinationID, d.Timeout(schema.TimeoutCreate)); err != nil {
+			return err
+		}
 	}
 
-	d.SetId(fmt.Sprintf("%s:%s", d.Get("detector_id"), *output.DestinationId))
-
 	return resourceAwsGuardDutyPublishingDestinationRead(d, meta)
 }
 
@@ -105,6 +186,27 @@ func guardDutyPublishingDestinationRefreshStatusFunc(conn *guardduty.GuardDuty,
 	}
 }
 
+// waitForGuardDutyPublishingDestinationPublishing waits for a publishing
+// destination to move out of PENDING_VERIFICATION and into PUBLISHING,
+// surfacing the destination ID in the returned error if verification fails.
+func waitForGuardDutyPublishingDestinationPublishing(conn *guardduty.GuardDuty, detectorID, destinationID string, timeout time.Duration) error {
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{guardduty.PublishingStatusPendingVerification},
+		Target:     []string{guardduty.PublishingStatusPublishing},
+		Refresh:    guardDutyPublishingDestinationRefreshStatusFunc(conn, destinationID, detectorID),
+		Timeout:    timeout,
+		MinTimeout: 3 * time.Second,
+	}
+
+	_, err := stateConf.WaitForState()
+	if err != nil {
+		return fmt.Errorf("Error waiting for GuardDuty PublishingDestination (%s:%s) status to be \"%s\": %s",
+			detectorID, destinationID, guardduty.PublishingStatusPublishing, err)
+	}
+
+	return nil
+}
+
 func resourceAwsGuardDutyPublishingDestinationRead(d *schema.ResourceData, meta interface{}) error {
 	conn := meta.(*AWSClient).guarddutyconn
 
@@ -135,6 +237,9 @@ func resourceAwsGuardDutyPublishingDestinationRead(d *schema.ResourceData, meta
 	d.Set("kms_key_arn", gdo.DestinationProperties.KmsKeyArn)
 	d.Set("destination_arn", gdo.DestinationProperties.DestinationArn)
 	d.Set("status", gdo.Status)
+	if _, ok := d.GetOkExists("wait_for_publishing"); !ok {
+		d.Set("wait_for_publishing", true)
+	}
 	return nil
 }
 
@@ -147,13 +252,13 @@ func resourceAwsGuardDutyPublishingDestinationUpdate(d *schema.ResourceData, met
 		return errStateRead
 	}
 
+	destinationArn := d.Get("destination_arn").(string)
+	kmsKeyArn := d.Get("kms_key_arn").(string)
+
 	input := guardduty.UpdatePublishingDestinationInput{
-		DestinationId: aws.String(destinationId),
-		DetectorId:    aws.String(detectorId),
-		DestinationProperties: &guardduty.DestinationProperties{
-			DestinationArn: aws.String(d.Get("destination_arn").(string)),
-			KmsKeyArn:      aws.String(d.Get("kms_key_arn").(string)),
-		},
+		DestinationId:         aws.String(destinationId),
+		DetectorId:            aws.String(detectorId),
+		DestinationProperties: guardDutyPublishingDestinationProperties(destinationArn, kmsKeyArn),
 	}
 
 	log.Printf("[DEBUG] Update GuardDuty publishing destination: %s", input)
@@ -162,6 +267,14 @@ func resourceAwsGuardDutyPublishingDestinationUpdate(d *schema.ResourceData, met
 		return fmt.Errorf("Updating GuardDuty publishing destination '%s' failed: %s", d.Id(), err.Error())
 	}
 
+	// Changing the destination or KMS key triggers GuardDuty to re-verify
+	// access, so wait for it to settle back into PUBLISHING just like Create.
+	if d.Get("wait_for_publishing").(bool) {
+		if err := waitForGuardDutyPublishingDestinationPublishing(conn, detectorId, destinationId, d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return err
+		}
+	}
+
 	return resourceAwsGuardDutyPublishingDestinationRead(d, meta)
 }
 
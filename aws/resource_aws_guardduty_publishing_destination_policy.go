@@ -0,0 +1,558 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// Sids GuardDuty needs on the destination's resource policy before it will
+// leave PENDING_VERIFICATION. Kept stable so they can be recognized and
+// removed again on destroy.
+const (
+	guardDutyPublishingDestinationS3PutObjectSid        = "AWSGuardDutyPutObject"
+	guardDutyPublishingDestinationS3GetLocationSid      = "AWSGuardDutyGetBucketLocation"
+	guardDutyPublishingDestinationKmsGenerateDataKeySid = "AWSGuardDutyGenerateDataKey"
+
+	guardDutyPublishingDestinationServicePrincipal = "guardduty.amazonaws.com"
+)
+
+// resourceAwsGuardDutyPublishingDestinationPolicy manages just the bucket/KMS
+// key policy statements GuardDuty needs to publish findings to a destination,
+// similar in spirit to aws_cloudwatch_log_destination_policy. It is a
+// separate, opt-in resource rather than a side effect of
+// aws_guardduty_publishing_destination's own lifecycle so that it can be
+// diffed and destroyed independently. It does not support import.
+//
+// It merges its statements into whatever policy document already exists on
+// the target bucket/key and only ever adds or removes the Sids it owns. If
+// the same bucket or key also has its policy fully managed elsewhere (e.g. an
+// aws_s3_bucket_policy or the aws_kms_key "policy" argument), that resource's
+// next apply will overwrite the whole document and strip these statements
+// again on the following apply of this resource - do not use both against
+// the same bucket/key.
+func resourceAwsGuardDutyPublishingDestinationPolicy() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceAwsGuardDutyPublishingDestinationPolicyCreate,
+		Read:   resourceAwsGuardDutyPublishingDestinationPolicyRead,
+		Delete: resourceAwsGuardDutyPublishingDestinationPolicyDelete,
+
+		Schema: map[string]*schema.Schema{
+			"destination_type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(guardDutyPublishingDestinationType_Values(), false),
+			},
+			"destination_arn": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateArn,
+			},
+			"kms_key_arn": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validateArn,
+			},
+		},
+	}
+}
+
+func resourceAwsGuardDutyPublishingDestinationPolicyCreate(d *schema.ResourceData, meta interface{}) error {
+	destinationType := d.Get("destination_type").(string)
+	destinationArn := d.Get("destination_arn").(string)
+	kmsKeyArn := d.Get("kms_key_arn").(string)
+
+	if err := guardDutyMergeDestinationPolicies(meta, destinationType, destinationArn, kmsKeyArn); err != nil {
+		return err
+	}
+
+	d.SetId(destinationArn)
+
+	return resourceAwsGuardDutyPublishingDestinationPolicyRead(d, meta)
+}
+
+func resourceAwsGuardDutyPublishingDestinationPolicyRead(d *schema.ResourceData, meta interface{}) error {
+	destinationType := d.Get("destination_type").(string)
+	destinationArn := d.Get("destination_arn").(string)
+	kmsKeyArn := d.Get("kms_key_arn").(string)
+
+	present, err := guardDutyDestinationPoliciesPresent(meta, destinationType, destinationArn, kmsKeyArn)
+	if err != nil {
+		return err
+	}
+
+	if !present {
+		log.Printf("[WARN] GuardDuty publishing destination policy for %q no longer present, removing from state", destinationArn)
+		d.SetId("")
+	}
+
+	return nil
+}
+
+func resourceAwsGuardDutyPublishingDestinationPolicyDelete(d *schema.ResourceData, meta interface{}) error {
+	destinationType := d.Get("destination_type").(string)
+	destinationArn := d.Get("destination_arn").(string)
+	kmsKeyArn := d.Get("kms_key_arn").(string)
+
+	return guardDutyRemoveDestinationPolicies(meta, destinationType, destinationArn, kmsKeyArn)
+}
+
+type guardDutyIAMPolicyStatement struct {
+	Sid          string      `json:"Sid,omitempty"`
+	Effect       string      `json:"Effect"`
+	Principal    interface{} `json:"Principal,omitempty"`
+	NotPrincipal interface{} `json:"NotPrincipal,omitempty"`
+	Action       interface{} `json:"Action,omitempty"`
+	NotAction    interface{} `json:"NotAction,omitempty"`
+	Resource     interface{} `json:"Resource,omitempty"`
+	NotResource  interface{} `json:"NotResource,omitempty"`
+	Condition    interface{} `json:"Condition,omitempty"`
+}
+
+type guardDutyIAMPolicyDoc struct {
+	Version   string                        `json:"Version"`
+	Id        string                        `json:"Id,omitempty"`
+	Statement []guardDutyIAMPolicyStatement `json:"Statement"`
+}
+
+func guardDutyRequiredS3BucketStatements(bucketArn string) []guardDutyIAMPolicyStatement {
+	return []guardDutyIAMPolicyStatement{
+		{
+			Sid:       guardDutyPublishingDestinationS3PutObjectSid,
+			Effect:    "Allow",
+			Principal: map[string]interface{}{"Service": guardDutyPublishingDestinationServicePrincipal},
+			Action:    "s3:PutObject",
+			Resource:  fmt.Sprintf("%s/*", bucketArn),
+		},
+		{
+			Sid:       guardDutyPublishingDestinationS3GetLocationSid,
+			Effect:    "Allow",
+			Principal: map[string]interface{}{"Service": guardDutyPublishingDestinationServicePrincipal},
+			Action:    "s3:GetBucketLocation",
+			Resource:  bucketArn,
+		},
+	}
+}
+
+func guardDutyRequiredKmsKeyStatements(kmsKeyArn string) []guardDutyIAMPolicyStatement {
+	return []guardDutyIAMPolicyStatement{
+		{
+			Sid:       guardDutyPublishingDestinationKmsGenerateDataKeySid,
+			Effect:    "Allow",
+			Principal: map[string]interface{}{"Service": guardDutyPublishingDestinationServicePrincipal},
+			Action:    "kms:GenerateDataKey",
+			Resource:  kmsKeyArn,
+		},
+	}
+}
+
+// guardDutyMergeDestinationPolicies merges the statements GuardDuty needs
+// into the bucket and/or KMS key policy for a destination.
+func guardDutyMergeDestinationPolicies(meta interface{}, destinationType, destinationArn, kmsKeyArn string) error {
+	if destinationType == guardduty.DestinationTypeS3 {
+		if err := guardDutyMergeS3BucketPolicy(meta, destinationArn); err != nil {
+			return err
+		}
+	}
+
+	if kmsKeyArn != "" {
+		if err := guardDutyMergeKmsKeyPolicy(meta, kmsKeyArn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// guardDutyRemoveDestinationPolicies undoes what
+// guardDutyMergeDestinationPolicies added.
+func guardDutyRemoveDestinationPolicies(meta interface{}, destinationType, destinationArn, kmsKeyArn string) error {
+	if destinationType == guardduty.DestinationTypeS3 {
+		if err := guardDutyRemoveS3BucketPolicy(meta, destinationArn); err != nil {
+			return err
+		}
+	}
+
+	if kmsKeyArn != "" {
+		if err := guardDutyRemoveKmsKeyPolicy(meta, kmsKeyArn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// guardDutyDestinationPoliciesPresent reports whether the statements this
+// resource is responsible for are still present, used by Read to detect a
+// policy document that was overwritten out-of-band.
+func guardDutyDestinationPoliciesPresent(meta interface{}, destinationType, destinationArn, kmsKeyArn string) (bool, error) {
+	if destinationType == guardduty.DestinationTypeS3 {
+		bucket := guardDutyS3BucketNameFromArn(destinationArn)
+		conn := meta.(*AWSClient).s3conn
+
+		doc, err := guardDutyGetS3BucketPolicyDoc(conn, bucket)
+		if err != nil {
+			if isAWSErr(err, s3.ErrCodeNoSuchBucket, "") {
+				return false, nil
+			}
+			return false, fmt.Errorf("error reading policy for S3 bucket %q: %w", bucket, err)
+		}
+
+		for _, stmt := range guardDutyRequiredS3BucketStatements(destinationArn) {
+			if !guardDutyPolicyDocHasStatement(doc, stmt.Sid) {
+				return false, nil
+			}
+		}
+	}
+
+	if kmsKeyArn != "" {
+		conn := meta.(*AWSClient).kmsconn
+
+		doc, err := guardDutyGetKmsKeyPolicyDoc(conn, kmsKeyArn)
+		if err != nil {
+			if isAWSErr(err, kms.ErrCodeNotFoundException, "") {
+				return false, nil
+			}
+			return false, fmt.Errorf("error reading policy for KMS key %q: %w", kmsKeyArn, err)
+		}
+
+		for _, stmt := range guardDutyRequiredKmsKeyStatements(kmsKeyArn) {
+			if !guardDutyPolicyDocHasStatement(doc, stmt.Sid) {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// guardDutyPreflightCheckDestinationPolicies is a read-only check called
+// from aws_guardduty_publishing_destination's Create. It surfaces a clear
+// diagnostic up front when the required statements are missing, rather than
+// letting the destination sit in PENDING_VERIFICATION until it times out. It
+// never writes to the bucket/key policy itself - see
+// aws_guardduty_publishing_destination_policy for that.
+func guardDutyPreflightCheckDestinationPolicies(meta interface{}, destinationType, destinationArn, kmsKeyArn string) error {
+	if destinationType == guardduty.DestinationTypeS3 {
+		bucket := guardDutyS3BucketNameFromArn(destinationArn)
+		conn := meta.(*AWSClient).s3conn
+
+		doc, err := guardDutyGetS3BucketPolicyDoc(conn, bucket)
+		if err != nil {
+			log.Printf("[WARN] Unable to read policy for S3 bucket %q to verify GuardDuty access, skipping pre-flight check: %s", bucket, err)
+			return nil
+		}
+
+		if err := guardDutyPreflightCheckStatements(doc, guardDutyRequiredS3BucketStatements(destinationArn), fmt.Sprintf("S3 bucket %q", bucket)); err != nil {
+			return err
+		}
+	}
+
+	if kmsKeyArn != "" {
+		conn := meta.(*AWSClient).kmsconn
+
+		doc, err := guardDutyGetKmsKeyPolicyDoc(conn, kmsKeyArn)
+		if err != nil {
+			log.Printf("[WARN] Unable to read policy for KMS key %q to verify GuardDuty access, skipping pre-flight check: %s", kmsKeyArn, err)
+			return nil
+		}
+
+		if err := guardDutyPreflightCheckStatements(doc, guardDutyRequiredKmsKeyStatements(kmsKeyArn), fmt.Sprintf("KMS key %q", kmsKeyArn)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// guardDutyPreflightCheckStatements only cares whether some statement in doc
+// already grants what's required, not whether it's the exact statement this
+// provider would have written - a hand-authored policy (or one using its own
+// Sids) satisfies it just as well. It must not match on Sid, since the Sids
+// in required are just this provider's own naming convention.
+func guardDutyPreflightCheckStatements(doc *guardDutyIAMPolicyDoc, required []guardDutyIAMPolicyStatement, target string) error {
+	for _, stmt := range required {
+		if !guardDutyPolicyDocHasEquivalentStatement(doc, stmt) {
+			return fmt.Errorf(
+				"%s is missing the policy statement GuardDuty needs to publish findings (allow %q on %v for %s). "+
+					"Add it to the resource policy, or use aws_guardduty_publishing_destination_policy to have Terraform manage it",
+				target, stmt.Action, stmt.Resource, guardDutyPublishingDestinationServicePrincipal)
+		}
+	}
+	return nil
+}
+
+func guardDutyMergeS3BucketPolicy(meta interface{}, bucketArn string) error {
+	bucket := guardDutyS3BucketNameFromArn(bucketArn)
+	conn := meta.(*AWSClient).s3conn
+
+	doc, err := guardDutyGetS3BucketPolicyDoc(conn, bucket)
+	if err != nil {
+		return fmt.Errorf("error reading policy for S3 bucket %q: %w", bucket, err)
+	}
+
+	if guardDutyPolicyDocMergeStatements(doc, guardDutyRequiredS3BucketStatements(bucketArn)) {
+		if err := guardDutyPutS3BucketPolicyDoc(conn, bucket, doc); err != nil {
+			return fmt.Errorf("error updating policy for S3 bucket %q: %w", bucket, err)
+		}
+	}
+
+	return nil
+}
+
+func guardDutyRemoveS3BucketPolicy(meta interface{}, bucketArn string) error {
+	bucket := guardDutyS3BucketNameFromArn(bucketArn)
+	conn := meta.(*AWSClient).s3conn
+
+	doc, err := guardDutyGetS3BucketPolicyDoc(conn, bucket)
+	if err != nil {
+		if isAWSErr(err, s3.ErrCodeNoSuchBucket, "") {
+			return nil
+		}
+		return fmt.Errorf("error reading policy for S3 bucket %q: %w", bucket, err)
+	}
+
+	if !guardDutyPolicyDocRemoveStatements(doc, guardDutyPublishingDestinationS3PutObjectSid, guardDutyPublishingDestinationS3GetLocationSid) {
+		return nil
+	}
+
+	if len(doc.Statement) == 0 {
+		_, err := conn.DeleteBucketPolicy(&s3.DeleteBucketPolicyInput{Bucket: aws.String(bucket)})
+		if err != nil && !isAWSErr(err, s3.ErrCodeNoSuchBucket, "") {
+			return fmt.Errorf("error deleting policy for S3 bucket %q: %w", bucket, err)
+		}
+		return nil
+	}
+
+	return guardDutyPutS3BucketPolicyDoc(conn, bucket, doc)
+}
+
+func guardDutyMergeKmsKeyPolicy(meta interface{}, kmsKeyArn string) error {
+	conn := meta.(*AWSClient).kmsconn
+
+	doc, err := guardDutyGetKmsKeyPolicyDoc(conn, kmsKeyArn)
+	if err != nil {
+		return fmt.Errorf("error reading policy for KMS key %q: %w", kmsKeyArn, err)
+	}
+
+	if guardDutyPolicyDocMergeStatements(doc, guardDutyRequiredKmsKeyStatements(kmsKeyArn)) {
+		if err := guardDutyPutKmsKeyPolicyDoc(conn, kmsKeyArn, doc); err != nil {
+			return fmt.Errorf("error updating policy for KMS key %q: %w", kmsKeyArn, err)
+		}
+	}
+
+	return nil
+}
+
+func guardDutyRemoveKmsKeyPolicy(meta interface{}, kmsKeyArn string) error {
+	conn := meta.(*AWSClient).kmsconn
+
+	doc, err := guardDutyGetKmsKeyPolicyDoc(conn, kmsKeyArn)
+	if err != nil {
+		if isAWSErr(err, kms.ErrCodeNotFoundException, "") {
+			return nil
+		}
+		return fmt.Errorf("error reading policy for KMS key %q: %w", kmsKeyArn, err)
+	}
+
+	if !guardDutyPolicyDocRemoveStatements(doc, guardDutyPublishingDestinationKmsGenerateDataKeySid) {
+		return nil
+	}
+
+	return guardDutyPutKmsKeyPolicyDoc(conn, kmsKeyArn, doc)
+}
+
+func guardDutyS3BucketNameFromArn(bucketArn string) string {
+	name := strings.TrimPrefix(bucketArn, "arn:aws:s3:::")
+	return strings.TrimPrefix(name, "arn:aws-us-gov:s3:::")
+}
+
+func guardDutyGetS3BucketPolicyDoc(conn *s3.S3, bucket string) (*guardDutyIAMPolicyDoc, error) {
+	output, err := conn.GetBucketPolicy(&s3.GetBucketPolicyInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		if isAWSErr(err, s3.ErrCodeNoSuchBucketPolicy, "") {
+			return &guardDutyIAMPolicyDoc{Version: "2012-10-17"}, nil
+		}
+		return nil, err
+	}
+
+	var doc guardDutyIAMPolicyDoc
+	if err := json.Unmarshal([]byte(aws.StringValue(output.Policy)), &doc); err != nil {
+		return nil, fmt.Errorf("error parsing policy for S3 bucket %q: %w", bucket, err)
+	}
+	return &doc, nil
+}
+
+func guardDutyPutS3BucketPolicyDoc(conn *s3.S3, bucket string, doc *guardDutyIAMPolicyDoc) error {
+	policy, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.PutBucketPolicy(&s3.PutBucketPolicyInput{
+		Bucket: aws.String(bucket),
+		Policy: aws.String(string(policy)),
+	})
+	return err
+}
+
+func guardDutyGetKmsKeyPolicyDoc(conn *kms.KMS, keyID string) (*guardDutyIAMPolicyDoc, error) {
+	output, err := conn.GetKeyPolicy(&kms.GetKeyPolicyInput{
+		KeyId:      aws.String(keyID),
+		PolicyName: aws.String("default"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var doc guardDutyIAMPolicyDoc
+	if err := json.Unmarshal([]byte(aws.StringValue(output.Policy)), &doc); err != nil {
+		return nil, fmt.Errorf("error parsing policy for KMS key %q: %w", keyID, err)
+	}
+	return &doc, nil
+}
+
+func guardDutyPutKmsKeyPolicyDoc(conn *kms.KMS, keyID string, doc *guardDutyIAMPolicyDoc) error {
+	policy, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.PutKeyPolicy(&kms.PutKeyPolicyInput{
+		KeyId:      aws.String(keyID),
+		PolicyName: aws.String("default"),
+		Policy:     aws.String(string(policy)),
+	})
+	return err
+}
+
+func guardDutyPolicyDocHasStatement(doc *guardDutyIAMPolicyDoc, sid string) bool {
+	for _, stmt := range doc.Statement {
+		if stmt.Sid == sid {
+			return true
+		}
+	}
+	return false
+}
+
+// guardDutyPolicyDocHasEquivalentStatement reports whether doc already grants
+// what required describes - same effect, principal, action and resource -
+// regardless of Sid. Used for the read-only pre-flight check, which has no
+// business caring what a statement is named as long as it does the job.
+func guardDutyPolicyDocHasEquivalentStatement(doc *guardDutyIAMPolicyDoc, required guardDutyIAMPolicyStatement) bool {
+	for _, stmt := range doc.Statement {
+		if stmt.Effect != required.Effect {
+			continue
+		}
+		if !guardDutyPolicyPrincipalContains(stmt.Principal, required.Principal) {
+			continue
+		}
+		if !guardDutyPolicyValueContains(stmt.Action, required.Action) {
+			continue
+		}
+		if !guardDutyPolicyValueContains(stmt.Resource, required.Resource) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// guardDutyPolicyPrincipalContains reports whether have, an IAM policy
+// Principal value as decoded from JSON, grants the service principal named
+// in want (a map[string]interface{}{"Service": ...} as built by
+// guardDutyRequiredS3BucketStatements/guardDutyRequiredKmsKeyStatements).
+func guardDutyPolicyPrincipalContains(have, want interface{}) bool {
+	wantService, ok := want.(map[string]interface{})["Service"].(string)
+	if !ok {
+		return false
+	}
+
+	haveMap, ok := have.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	return guardDutyPolicyValueContains(haveMap["Service"], wantService)
+}
+
+// guardDutyPolicyValueContains reports whether have, a JSON-decoded IAM
+// policy Action/Resource/Service value (either a single string or a list of
+// strings), contains the single string want. Entries in have are matched as
+// IAM wildcard patterns, so "*" and "?" in them match any run of characters
+// and any single character respectively.
+func guardDutyPolicyValueContains(have interface{}, want interface{}) bool {
+	wantStr, ok := want.(string)
+	if !ok {
+		return false
+	}
+
+	matches := func(pattern string) bool {
+		if pattern == wantStr {
+			return true
+		}
+		expr := strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(pattern))
+		matched, _ := regexp.MatchString("^"+expr+"$", wantStr)
+		return matched
+	}
+
+	switch v := have.(type) {
+	case string:
+		return matches(v)
+	case []interface{}:
+		for _, elem := range v {
+			if s, ok := elem.(string); ok && matches(s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func guardDutyPolicyDocMergeStatements(doc *guardDutyIAMPolicyDoc, statements []guardDutyIAMPolicyStatement) bool {
+	if doc.Version == "" {
+		doc.Version = "2012-10-17"
+	}
+
+	changed := false
+	for _, stmt := range statements {
+		if !guardDutyPolicyDocHasStatement(doc, stmt.Sid) {
+			doc.Statement = append(doc.Statement, stmt)
+			changed = true
+		}
+	}
+	return changed
+}
+
+func guardDutyPolicyDocRemoveStatements(doc *guardDutyIAMPolicyDoc, sids ...string) bool {
+	changed := false
+	kept := doc.Statement[:0]
+	for _, stmt := range doc.Statement {
+		remove := false
+		for _, sid := range sids {
+			if stmt.Sid == sid {
+				remove = true
+				break
+			}
+		}
+		if remove {
+			changed = true
+			continue
+		}
+		kept = append(kept, stmt)
+	}
+	doc.Statement = kept
+	return changed
+}
@@ -0,0 +1,65 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+)
+
+func TestAccAwsGuardDutyPublishingDestinationPolicy_basic(t *testing.T) {
+	resourceName := "aws_guardduty_publishing_destination_policy.test"
+	destinationResourceName := "aws_guardduty_publishing_destination.test"
+	bucketName := fmt.Sprintf("tf-test-%s", acctest.RandString(5))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckAwsGuardDutyPublishingDestinationDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAwsGuardDutyPublishingDestinationPolicyConfig_basic(bucketName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "destination_type", "S3"),
+					resource.TestCheckResourceAttrPair(resourceName, "destination_arn", destinationResourceName, "destination_arn"),
+					resource.TestCheckResourceAttrPair(resourceName, "kms_key_arn", destinationResourceName, "kms_key_arn"),
+				),
+			},
+		},
+	})
+}
+
+func testAccAwsGuardDutyPublishingDestinationPolicyConfig_basic(bucketName string) string {
+	return fmt.Sprintf(`
+resource "aws_guardduty_detector" "test_gd" {
+  enable = true
+}
+
+resource "aws_s3_bucket" "gd_bucket" {
+  bucket        = %[1]q
+  force_destroy = true
+}
+
+resource "aws_kms_key" "gd_key" {
+  description             = "Temporary key for AccTest of TF"
+  deletion_window_in_days = 7
+}
+
+resource "aws_guardduty_publishing_destination_policy" "test" {
+  destination_type = "S3"
+  destination_arn  = aws_s3_bucket.gd_bucket.arn
+  kms_key_arn      = aws_kms_key.gd_key.arn
+}
+
+resource "aws_guardduty_publishing_destination" "test" {
+  detector_id     = aws_guardduty_detector.test_gd.id
+  destination_arn = aws_s3_bucket.gd_bucket.arn
+  kms_key_arn     = aws_kms_key.gd_key.arn
+
+  depends_on = [
+    aws_guardduty_publishing_destination_policy.test,
+  ]
+}
+`, bucketName)
+}
@@ -95,7 +95,32 @@ func TestAccAwsGuardDutyPublishDestination_basic(t *testing.T) {
 					resource.TestCheckResourceAttrSet(resourceName, "detector_id"),
 					resource.TestCheckResourceAttrSet(resourceName, "id"),
 					resource.TestCheckResourceAttrSet(resourceName, "destination_arn"),
-					resource.TestCheckResourceAttr(resourceName, "destination_type", "S3")),
+					resource.TestCheckResourceAttr(resourceName, "destination_type", "S3"),
+					resource.TestCheckResourceAttr(resourceName, "wait_for_publishing", "true"),
+					resource.TestCheckResourceAttr(resourceName, "status", "PUBLISHING")),
+			},
+		},
+	})
+}
+
+func TestAccAwsGuardDutyPublishDestination_kinesis(t *testing.T) {
+	resourceName := "aws_guardduty_publishing_destination.test"
+	streamName := fmt.Sprintf("tf-test-%s", acctest.RandString(5))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckAwsGuardDutyPublishingDestinationDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAwsGuardDutyPublishDestinationConfig_kinesis(streamName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckAwsGuardDutyPublishingDestinationExists(resourceName),
+					resource.TestCheckResourceAttrSet(resourceName, "detector_id"),
+					resource.TestCheckResourceAttrSet(resourceName, "id"),
+					resource.TestCheckResourceAttrSet(resourceName, "destination_arn"),
+					resource.TestCheckNoResourceAttr(resourceName, "kms_key_arn"),
+					resource.TestCheckResourceAttr(resourceName, "destination_type", "KINESIS")),
 			},
 		},
 	})
@@ -236,6 +261,25 @@ func testAccAwsGuardDutyPublishDestinationConfig_basic(bucketName string) string
 	`, strings.Replace(testAccGuardDutyDetectorDSConfig_basic1, "<<BUCKET_NAME>>", bucketName, 1))
 }
 
+func testAccAwsGuardDutyPublishDestinationConfig_kinesis(streamName string) string {
+	return fmt.Sprintf(`
+resource "aws_guardduty_detector" "test_gd" {
+  enable = true
+}
+
+resource "aws_kinesis_stream" "gd_stream" {
+  name        = %[1]q
+  shard_count = 1
+}
+
+resource "aws_guardduty_publishing_destination" "test" {
+  detector_id      = aws_guardduty_detector.test_gd.id
+  destination_type = "KINESIS"
+  destination_arn  = aws_kinesis_stream.gd_stream.arn
+}
+`, streamName)
+}
+
 func testAccCheckAwsGuardDutyPublishingDestinationExists(name string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[name]